@@ -0,0 +1,305 @@
+// Package cache fournit un cache de résultats de filtres adressé par
+// contenu, ainsi qu'un Warmer pour le peupler en parallèle à partir d'un
+// dossier de sources. Il ne dépend d'aucun filtre concret : l'appelant lui
+// fournit une ApplyFunc, ce qui le rend importable depuis un outil externe
+// (job CI, tâche nocturne, ...) plutôt que réservé à ce binaire.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cache est un cache de résultats de filtres adressé par contenu : la clé
+// d'une entrée est le SHA-256 de (octets source || filterSpec), et sa valeur
+// est le fichier produit par ce filtre, stocké sous dir. Une entrée
+// retrouvée (Fetch) ou ajoutée (Store) voit sa date de modification
+// rafraîchie, ce qui sert d'horodatage de dernier accès pour EvictLRU.
+type Cache struct {
+	dir string
+}
+
+// NewCache crée dir si nécessaire et renvoie un Cache qui y stocke ses
+// entrées.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache: création de %s: %w", dir, err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Key calcule la clé de cache pour l'application d'un filtre identifié par
+// filterSpec (une chaîne assemblée par l'appelant à partir du nom du filtre
+// et de ses paramètres) au fichier source srcPath, sans charger
+// l'intégralité du fichier en mémoire.
+func Key(srcPath, filterSpec string) (string, error) {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "|%s", filterSpec)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// path renvoie le chemin sur disque de l'entrée key, comme pour les objets
+// git : les deux premiers caractères de la clé forment un sous-dossier, pour
+// éviter d'accumuler toutes les entrées dans un seul répertoire.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Lookup indique si key est présente dans le cache et renvoie son chemin.
+func (c *Cache) Lookup(key string) (string, bool) {
+	p := c.path(key)
+	if _, err := os.Stat(p); err != nil {
+		return "", false
+	}
+	return p, true
+}
+
+// Fetch copie (ou, si le système de fichiers le permet, lie en dur) l'entrée
+// key vers destPath, et rafraîchit sa date de dernier accès.
+func (c *Cache) Fetch(key, destPath string) error {
+	p, ok := c.Lookup(key)
+	if !ok {
+		return fmt.Errorf("cache: entrée introuvable: %s", key)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return err
+	}
+
+	os.Remove(destPath)
+	if err := os.Link(p, destPath); err != nil {
+		if err := copyFile(p, destPath); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	os.Chtimes(p, now, now)
+	return nil
+}
+
+// Store enregistre srcPath dans le cache sous la clé key.
+func (c *Cache) Store(key, srcPath string) error {
+	p := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return copyFile(srcPath, p)
+}
+
+// copyFile copie le contenu de srcPath vers destPath.
+func copyFile(srcPath, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	_, err = io.Copy(dest, src)
+	return err
+}
+
+// entry décrit une entrée du cache rencontrée lors d'une marche du
+// répertoire, utilisée par Size et EvictLRU.
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// entries parcourt le cache et renvoie chacune de ses entrées.
+func (c *Cache) entries() ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return entries, err
+}
+
+// Size renvoie la taille totale en octets des entrées actuellement stockées
+// dans le cache.
+func (c *Cache) Size() (int64, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	return total, nil
+}
+
+// EvictLRU supprime les entrées les moins récemment utilisées (Fetch ou
+// Store) jusqu'à ce que la taille totale du cache ne dépasse plus maxBytes.
+func (c *Cache) EvictLRU(maxBytes int64) error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	if total <= maxBytes {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	for _, e := range entries {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err != nil {
+			continue
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+// ApplyFunc applique un filtre à srcPath et écrit le résultat à partir de
+// destPath, puis renvoie le chemin effectivement écrit : certains filtres
+// (par exemple une vignette suffixée "_thumb") n'écrivent pas exactement à
+// destPath, et Warmer a besoin du chemin réel pour stocker le bon fichier
+// dans le cache et nettoyer ses fichiers temporaires.
+type ApplyFunc func(ctx context.Context, srcPath, destPath string) (actualDestPath string, err error)
+
+// Warmer précalcule et peuple un Cache pour l'ensemble des fichiers d'un
+// dossier source, en parallèle sur plusieurs workers ; utile pour un job CI
+// ou nocturne qui souhaite qu'une exécution "à chaud" ultérieure ne
+// recalcule aucun filtre.
+type Warmer struct {
+	cache      *Cache
+	srcDir     string
+	filterSpec string
+	apply      ApplyFunc
+	workers    int
+}
+
+// NewWarmer construit un Warmer qui peuplera cache à partir des fichiers de
+// srcDir (sous-dossiers compris) en leur appliquant apply, sur workers
+// goroutines. filterSpec identifie le filtre et ses paramètres : c'est la
+// même valeur qui doit être passée à Key pour qu'un Warmer et un appelant
+// normal retrouvent les mêmes entrées.
+func NewWarmer(cache *Cache, srcDir, filterSpec string, apply ApplyFunc, workers int) *Warmer {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Warmer{cache: cache, srcDir: srcDir, filterSpec: filterSpec, apply: apply, workers: workers}
+}
+
+// Run parcourt srcDir et, pour chaque fichier dont la clé de cache est
+// encore absente, applique apply dans un fichier temporaire puis stocke le
+// résultat dans le cache. Elle s'arrête dès que ctx est annulé et renvoie
+// les erreurs rencontrées par fichier.
+func (w *Warmer) Run(ctx context.Context) []error {
+	jobs := make(chan string, w.workers)
+
+	go func() {
+		defer close(jobs)
+		filepath.WalkDir(w.srcDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || ctx.Err() != nil {
+				return nil
+			}
+			select {
+			case jobs <- path:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	wg.Add(w.workers)
+	for i := 0; i < w.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for srcPath := range jobs {
+				if err := w.warmOne(ctx, srcPath); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// warmOne applique apply à srcPath et stocke le résultat dans le cache, à
+// moins qu'une entrée n'y existe déjà. Le fichier temporaire passé à apply
+// et le chemin réellement écrit (si différent) sont tous deux nettoyés.
+func (w *Warmer) warmOne(ctx context.Context, srcPath string) error {
+	key, err := Key(srcPath, w.filterSpec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", srcPath, err)
+	}
+
+	if _, ok := w.cache.Lookup(key); ok {
+		return nil
+	}
+
+	tmpDest, err := os.CreateTemp("", "cache-warm-*"+filepath.Ext(srcPath))
+	if err != nil {
+		return fmt.Errorf("%s: %w", srcPath, err)
+	}
+	tmpDest.Close()
+	defer os.Remove(tmpDest.Name())
+
+	outPath, err := w.apply(ctx, srcPath, tmpDest.Name())
+	if err != nil {
+		return fmt.Errorf("%s: %w", srcPath, err)
+	}
+	defer os.Remove(outPath)
+
+	return w.cache.Store(key, outPath)
+}
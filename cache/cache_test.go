@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheStoreLookupFetch(t *testing.T) {
+	c, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+
+	src := filepath.Join(t.TempDir(), "src.png")
+	if err := os.WriteFile(src, []byte("fake image bytes"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	key, err := Key(src, "grayscale")
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	if _, ok := c.Lookup(key); ok {
+		t.Fatalf("Lookup: expected miss before Store")
+	}
+
+	if err := c.Store(key, src); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	if _, ok := c.Lookup(key); !ok {
+		t.Fatalf("Lookup: expected hit after Store")
+	}
+
+	dest := filepath.Join(t.TempDir(), "dest.png")
+	if err := c.Fetch(key, dest); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "fake image bytes" {
+		t.Fatalf("Fetch: got %q, want %q", got, "fake image bytes")
+	}
+}
+
+func TestCacheEvictLRU(t *testing.T) {
+	c, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+
+	src := filepath.Join(t.TempDir(), "src.png")
+	if err := os.WriteFile(src, make([]byte, 100), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var keys []string
+	for i := 0; i < 3; i++ {
+		key, err := Key(src, filepath.Join("grayscale", string(rune('a'+i))))
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+		if err := c.Store(key, src); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+		keys = append(keys, key)
+
+		// Espacer les dates de modification pour que l'ordre LRU soit déterministe.
+		p, _ := c.Lookup(key)
+		mtime := time.Now().Add(time.Duration(i) * time.Second)
+		os.Chtimes(p, mtime, mtime)
+	}
+
+	if err := c.EvictLRU(150); err != nil {
+		t.Fatalf("EvictLRU: %v", err)
+	}
+
+	if _, ok := c.Lookup(keys[0]); ok {
+		t.Fatalf("EvictLRU: expected oldest entry to be evicted")
+	}
+	if _, ok := c.Lookup(keys[2]); !ok {
+		t.Fatalf("EvictLRU: expected most recently used entry to survive")
+	}
+}
+
+func TestWarmerRun(t *testing.T) {
+	srcDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "a.png")
+	if err := os.WriteFile(srcFile, []byte("source bytes"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	c, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+
+	apply := func(ctx context.Context, srcPath, destPath string) (string, error) {
+		data, err := os.ReadFile(srcPath)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(destPath, data, 0o644); err != nil {
+			return "", err
+		}
+		return destPath, nil
+	}
+
+	warmer := NewWarmer(c, srcDir, "grayscale", apply, 2)
+	if errs := warmer.Run(context.Background()); len(errs) != 0 {
+		t.Fatalf("Run: unexpected errors: %v", errs)
+	}
+
+	key, err := Key(srcFile, "grayscale")
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	if _, ok := c.Lookup(key); !ok {
+		t.Fatalf("Run: expected cache entry for a.png")
+	}
+}
+
+// TestWarmerRunCleansRenamedOutput vérifie qu'une ApplyFunc qui écrit à un
+// chemin différent de destPath (par exemple un filtre qui suffixe son nom de
+// fichier) ne laisse aucun fichier temporaire derrière elle : ni le fichier
+// temporaire passé à apply, ni le chemin réellement écrit.
+func TestWarmerRunCleansRenamedOutput(t *testing.T) {
+	srcDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "a.png")
+	if err := os.WriteFile(srcFile, []byte("source bytes"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	c, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+
+	var renamedPath string
+	apply := func(ctx context.Context, srcPath, destPath string) (string, error) {
+		renamedPath = destPath + "_thumb"
+		data, err := os.ReadFile(srcPath)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(renamedPath, data, 0o644); err != nil {
+			return "", err
+		}
+		return renamedPath, nil
+	}
+
+	warmer := NewWarmer(c, srcDir, "thumbnail", apply, 1)
+	if errs := warmer.Run(context.Background()); len(errs) != 0 {
+		t.Fatalf("Run: unexpected errors: %v", errs)
+	}
+
+	if renamedPath == "" {
+		t.Fatal("Run: apply was never called")
+	}
+	if _, err := os.Stat(renamedPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Run: expected renamed output %s to be cleaned up, stat err = %v", renamedPath, err)
+	}
+}
@@ -1,17 +1,106 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"image"
+	"io/fs"
 	"io/ioutil"
+	"math"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	pb "github.com/cheggaaa/pb/v3"
 	"github.com/disintegration/imaging"
+
+	"github.com/deepyjr/image-go-exercice/cache"
+	"github.com/deepyjr/image-go-exercice/pipeline"
 )
 
+// thumbnailOptions regroupe les paramètres du filtre "thumbnail" : les
+// dimensions maximales de la vignette, et si elle doit être centre-rognée en
+// carré (imaging.Fill) plutôt que réduite en conservant son ratio d'aspect
+// (imaging.Resize).
+type thumbnailOptions struct {
+	maxWidth   int
+	maxHeight  int
+	cropSquare bool
+}
+
+// thumbSuffix insère "_thumb" juste avant l'extension de path, par exemple
+// thumbSuffix("photo.jpg") renvoie "photo_thumb.jpg".
+func thumbSuffix(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_thumb" + ext
+}
+
+// applyThumbnailFilter produit une vignette de l'image source. Par défaut
+// elle est réduite pour tenir dans opts.maxWidth x opts.maxHeight sans jamais
+// être agrandie ni déformée ; avec opts.cropSquare elle est centre-rognée en
+// carré puis redimensionnée à min(maxWidth, maxHeight) de côté. Comme les
+// autres filtres, le fichier de destination est nettoyé si ctx est annulé.
+func applyThumbnailFilter(ctx context.Context, srcPath, destPath string, opts thumbnailOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if opts.maxWidth <= 0 || opts.maxHeight <= 0 {
+		return fmt.Errorf("applyThumbnailFilter: maxWidth et maxHeight doivent être positifs (reçu %dx%d)", opts.maxWidth, opts.maxHeight)
+	}
+
+	// Ouvrir l'image source
+	srcImage, err := imaging.Open(srcPath)
+	if err != nil {
+		return err
+	}
+
+	var thumbImage image.Image
+	if opts.cropSquare {
+		size := opts.maxWidth
+		if opts.maxHeight < size {
+			size = opts.maxHeight
+		}
+		thumbImage = imaging.Fill(srcImage, size, size, imaging.Center, imaging.Lanczos)
+	} else {
+		bounds := srcImage.Bounds()
+		scale := math.Min(float64(opts.maxWidth)/float64(bounds.Dx()), float64(opts.maxHeight)/float64(bounds.Dy()))
+		if scale > 1 {
+			scale = 1
+		}
+		thumbImage = imaging.Resize(srcImage, int(float64(bounds.Dx())*scale), int(float64(bounds.Dy())*scale), imaging.Lanczos)
+	}
+
+	// Sauvegarder la vignette à côté de la destination attendue, suffixée de "_thumb"
+	destThumbPath := thumbSuffix(destPath)
+	if err := imaging.Save(thumbImage, destThumbPath); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		os.Remove(destThumbPath)
+		return err
+	}
+
+	return nil
+}
+
 // applyGrayscaleFilter applique le filtre de grayscale à une image donnée.
-func applyGrayscaleFilter(srcPath, destPath string) error {
+// Si ctx est annulé avant ou pendant le traitement, le fichier de destination
+// n'est pas laissé dans un état partiel : il est supprimé avant de renvoyer
+// l'erreur d'annulation.
+func applyGrayscaleFilter(ctx context.Context, srcPath, destPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// Ouvrir l'image source
 	srcImage, err := imaging.Open(srcPath)
 	if err != nil {
@@ -27,11 +116,21 @@ func applyGrayscaleFilter(srcPath, destPath string) error {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		os.Remove(destPath)
+		return err
+	}
+
 	return nil
 }
 
-// applyBlurFilter applique le filtre de blur à une image donnée.
-func applyBlurFilter(srcPath, destPath string) error {
+// applyBlurFilter applique le filtre de blur à une image donnée. Comme
+// applyGrayscaleFilter, il nettoie destPath si ctx est annulé.
+func applyBlurFilter(ctx context.Context, srcPath, destPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// Ouvrir l'image source
 	srcImage, err := imaging.Open(srcPath)
 	if err != nil {
@@ -47,24 +146,95 @@ func applyBlurFilter(srcPath, destPath string) error {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		os.Remove(destPath)
+		return err
+	}
+
 	return nil
 }
 
-// applyFilters parcourt le dossier source, applique les filtres spécifiés aux images et sauvegarde les images filtrées dans le dossier de destination.
-// Cette fonction est utilisée avec la méthode WaitGroup pour répartir les tâches en parallèle.
-func applyFilters(srcPath, destPath string, filter string, wg *sync.WaitGroup, ch chan string) {
-	// Marquer la fin de la tâche lorsque la fonction se termine
-	defer wg.Done()
+// filterSpec construit l'identifiant textuel d'un filtre et de ses
+// paramètres utilisé comme composant de la clé de cache (cache.Key), afin
+// que deux appels avec les mêmes filtre et thumbOpts retrouvent la même
+// entrée.
+func filterSpec(filter string, thumbOpts thumbnailOptions) string {
+	return fmt.Sprintf("%s|%d|%d|%t", filter, thumbOpts.maxWidth, thumbOpts.maxHeight, thumbOpts.cropSquare)
+}
+
+// runFilter applique filter à srcFilePath/destFilePath et renvoie le
+// FileResult correspondant (tailles, durée, erreur éventuelle). Partagée par
+// applyFilters et processImagesWithChannel pour que le rapport JSON reste
+// cohérent quelle que soit la stratégie de traitement utilisée. Si
+// resultCache n'est pas nil, un résultat déjà en cache pour (srcFilePath,
+// filter, thumbOpts) est réutilisé sans relancer le filtre, et tout résultat
+// calculé y est stocké pour les appels suivants.
+func runFilter(ctx context.Context, srcFilePath, destFilePath, filter string, thumbOpts thumbnailOptions, resultCache *cache.Cache) FileResult {
+	start := time.Now()
+	result := FileResult{InputPath: srcFilePath, OutputPath: destFilePath, FilterChain: filter}
+	if filter == "thumbnail" {
+		result.OutputPath = thumbSuffix(destFilePath)
+	}
+
+	if srcInfo, err := os.Stat(srcFilePath); err == nil {
+		result.InputSize = srcInfo.Size()
+	}
+
+	var key string
+	if resultCache != nil {
+		if k, err := cache.Key(srcFilePath, filterSpec(filter, thumbOpts)); err == nil {
+			key = k
+			if err := resultCache.Fetch(key, result.OutputPath); err == nil {
+				if destInfo, statErr := os.Stat(result.OutputPath); statErr == nil {
+					result.OutputSize = destInfo.Size()
+				}
+				result.CacheHit = true
+				result.ElapsedMS = float64(time.Since(start).Microseconds()) / 1000
+				return result
+			}
+		}
+	}
+
+	var err error
+	switch filter {
+	case "grayscale":
+		err = applyGrayscaleFilter(ctx, srcFilePath, destFilePath)
+	case "blur":
+		err = applyBlurFilter(ctx, srcFilePath, destFilePath)
+	case "thumbnail":
+		err = applyThumbnailFilter(ctx, srcFilePath, destFilePath, thumbOpts)
+	default:
+		err = fmt.Errorf("invalid filter: %s", filter)
+	}
 
-	// Lire la liste des fichiers du dossier source
-	fileList, err := ioutil.ReadDir(srcPath)
 	if err != nil {
-		fmt.Printf("Error reading directory: %s\n", err.Error())
-		return
+		result.Error = err.Error()
+	} else {
+		if destInfo, statErr := os.Stat(result.OutputPath); statErr == nil {
+			result.OutputSize = destInfo.Size()
+		}
+		if resultCache != nil && key != "" {
+			resultCache.Store(key, result.OutputPath)
+		}
 	}
 
+	result.ElapsedMS = float64(time.Since(start).Microseconds()) / 1000
+	return result
+}
+
+// applyFilters parcourt fileList, applique filter à chaque fichier et publie le FileResult correspondant sur ch.
+// Cette fonction est utilisée avec la méthode WaitGroup pour répartir les tâches en parallèle.
+// Elle s'arrête dès que ctx est annulé, sans bloquer sur l'envoi au canal ch.
+func applyFilters(ctx context.Context, srcPath, destPath string, filter string, thumbOpts thumbnailOptions, resultCache *cache.Cache, fileList []os.FileInfo, wg *sync.WaitGroup, ch chan FileResult) {
+	// Marquer la fin de la tâche lorsque la fonction se termine
+	defer wg.Done()
+
 	// Parcourir chaque fichier du dossier source
 	for _, file := range fileList {
+		if ctx.Err() != nil {
+			return
+		}
+
 		if file.IsDir() {
 			continue
 		}
@@ -73,35 +243,34 @@ func applyFilters(srcPath, destPath string, filter string, wg *sync.WaitGroup, c
 		srcFilePath := filepath.Join(srcPath, fileName)
 		destFilePath := filepath.Join(destPath, fileName)
 
-		// Appliquer le filtre spécifié à l'image
-		switch filter {
-		case "grayscale":
-			err := applyGrayscaleFilter(srcFilePath, destFilePath)
-			if err != nil {
-				fmt.Printf("Error applying grayscale filter to %s: %s\n", fileName, err.Error())
-			}
-		case "blur":
-			err := applyBlurFilter(srcFilePath, destFilePath)
-			if err != nil {
-				fmt.Printf("Error applying blur filter to %s: %s\n", fileName, err.Error())
-			}
-		default:
-			fmt.Printf("Invalid filter: %s\n", filter)
-		}
+		result := runFilter(ctx, srcFilePath, destFilePath, filter, thumbOpts, resultCache)
 
-		// Envoyer le nom du fichier traité via le canal pour afficher une notification
-		ch <- fileName
+		// Envoyer le résultat via le canal pour mettre à jour la barre de progression et le rapport
+		select {
+		case ch <- result:
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
 // processImagesWithWaitGroup génère la liste des fichiers à traiter et dispatche les tâches de filtrage sur les images en utilisant la méthode WaitGroup.
-func processImagesWithWaitGroup(srcPath, destPath, filter string) {
+func processImagesWithWaitGroup(ctx context.Context, srcPath, destPath, filter string, thumbOpts thumbnailOptions, progress progressOptions, resultCache *cache.Cache) {
+	// Lire la liste des fichiers du dossier source
+	fileList, err := ioutil.ReadDir(srcPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading directory: %s\n", err.Error())
+		return
+	}
+
+	bar := newProgressBar(progress, len(fileList), totalSize(fileList))
+
 	var wg sync.WaitGroup
-	ch := make(chan string)
+	ch := make(chan FileResult)
 
 	// Ajouter une tâche au WaitGroup pour la fonction applyFilters
 	wg.Add(1)
-	go applyFilters(srcPath, destPath, filter, &wg, ch)
+	go applyFilters(ctx, srcPath, destPath, filter, thumbOpts, resultCache, fileList, &wg, ch)
 
 	// Attendre la fin de toutes les tâches en utilisant le WaitGroup
 	go func() {
@@ -109,82 +278,339 @@ func processImagesWithWaitGroup(srcPath, destPath, filter string) {
 		close(ch)
 	}()
 
-	// Lire les messages du canal pour afficher les fichiers traités
-	for fileName := range ch {
-		fmt.Printf("Finished processing: %s\n", fileName)
+	writeReport(collectResults(ch, bar), progress)
+}
+
+// totalSize additionne la taille des fichiers (hors sous-dossiers) de fileList.
+func totalSize(fileList []os.FileInfo) int64 {
+	var total int64
+	for _, file := range fileList {
+		if !file.IsDir() {
+			total += file.Size()
+		}
 	}
+	return total
 }
 
-// processImagesWithChannel génère la liste des fichiers à traiter et dispatche les tâches de filtrage sur les images en utilisant des canaux.
-func processImagesWithChannel(srcPath, destPath, filter string) {
-	// Lire la liste des fichiers du dossier source
-	fileList, err := ioutil.ReadDir(srcPath)
+// fileJob décrit un fichier à traiter par le pool de workers : son chemin
+// source et le chemin de destination correspondant, qui mirrore
+// l'arborescence du dossier source sous destPath (sous-dossiers compris).
+type fileJob struct {
+	srcPath  string
+	destPath string
+}
+
+// countFiles parcourt récursivement srcPath et renvoie le nombre de fichiers
+// (hors dossiers) rencontrés ainsi que leur taille totale, afin de
+// dimensionner la barre de progression avant de lancer le traitement.
+func countFiles(srcPath string) (count int, totalBytes int64, err error) {
+	err = filepath.WalkDir(srcPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		count++
+		totalBytes += info.Size()
+		return nil
+	})
+	return count, totalBytes, err
+}
+
+// walkJobs parcourt récursivement srcPath et publie un fileJob par fichier
+// rencontré sur jobs, en créant au fur et à mesure sous destPath les
+// sous-dossiers correspondants. Elle ferme jobs une fois la marche terminée,
+// que ce soit normalement ou parce que ctx a été annulé.
+func walkJobs(ctx context.Context, srcPath, destPath string, jobs chan<- fileJob) error {
+	defer close(jobs)
+	return filepath.WalkDir(srcPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		destFilePath := filepath.Join(destPath, rel)
+
+		if d.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			return os.MkdirAll(destFilePath, 0o755)
+		}
+
+		select {
+		case jobs <- fileJob{srcPath: path, destPath: destFilePath}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+}
+
+// processImagesWithChannel parcourt srcPath (sous-dossiers compris) et
+// dispatche les fichiers trouvés sur un pool borné de workers goroutines,
+// plutôt que d'en lancer une par fichier : un goroutine de marche alimente
+// un canal jobs de taille fixe, et workers goroutines le consomment jusqu'à
+// épuisement, ce qui borne la mémoire et les descripteurs de fichiers
+// ouverts simultanément quelle que soit la taille du dossier source.
+func processImagesWithChannel(ctx context.Context, srcPath, destPath, filter string, thumbOpts thumbnailOptions, progress progressOptions, resultCache *cache.Cache, workers int) {
+	fileCount, totalBytes, err := countFiles(srcPath)
 	if err != nil {
-		fmt.Printf("Error reading directory: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "Error reading directory: %s\n", err.Error())
 		return
 	}
 
-	// Créer un canal avec une taille équivalente au nombre de fichiers pour éviter les blocages
-	ch := make(chan string, len(fileList))
+	bar := newProgressBar(progress, fileCount, totalBytes)
 
-	var wg sync.WaitGroup
-	for i := 0; i < len(fileList); i++ {
-		wg.Add(1)
-		go func(index int) {
-			// Marquer la fin de la tâche lorsque la fonction se termine
-			defer wg.Done()
+	if workers < 1 {
+		workers = 1
+	}
 
-			file := fileList[index]
-			if file.IsDir() {
-				return
-			}
+	jobs := make(chan fileJob, workers)
+	results := make(chan FileResult, workers)
 
-			fileName := file.Name()
-			srcFilePath := filepath.Join(srcPath, fileName)
-			destFilePath := filepath.Join(destPath, fileName)
+	go func() {
+		if err := walkJobs(ctx, srcPath, destPath, jobs); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Error walking %s: %s\n", srcPath, err.Error())
+		}
+	}()
 
-			// Appliquer le filtre spécifié à l'image
-			switch filter {
-			case "grayscale":
-				err := applyGrayscaleFilter(srcFilePath, destFilePath)
-				if err != nil {
-					fmt.Printf("Error applying grayscale filter to %s: %s\n", fileName, err.Error())
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if ctx.Err() != nil {
+					return
 				}
-			case "blur":
-				err := applyBlurFilter(srcFilePath, destFilePath)
-				if err != nil {
-					fmt.Printf("Error applying blur filter to %s: %s\n", fileName, err.Error())
+
+				result := runFilter(ctx, job.srcPath, job.destPath, filter, thumbOpts, resultCache)
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
 				}
-			default:
-				fmt.Printf("Invalid filter: %s\n", filter)
 			}
-
-			// Envoyer le nom du fichier traité via le canal pour afficher une notification
-			ch <- fileName
-		}(i)
+		}()
 	}
 
-	// Attendre la fin de toutes les tâches en utilisant le WaitGroup
 	go func() {
 		wg.Wait()
-		close(ch)
+		close(results)
 	}()
 
-	// Lire les messages du canal pour afficher les fichiers traités
-	for fileName := range ch {
-		fmt.Printf("Finished processing: %s\n", fileName)
+	writeReport(collectResults(results, bar), progress)
+}
+
+// pipelineReport convertit les pipeline.Result renvoyés par pipeline.Run en
+// un Report, en faisant avancer bar (si non nil) et en journalisant les
+// erreurs par fichier sur stderr, de la même façon que collectResults pour
+// les chemins -task.
+func pipelineReport(results []pipeline.Result, filterChain string, bar *pb.ProgressBar) Report {
+	var report Report
+	for _, result := range results {
+		fileResult := FileResult{
+			InputPath:   result.SrcPath,
+			OutputPath:  result.DestPath,
+			FilterChain: filterChain,
+		}
+		if info, err := os.Stat(result.SrcPath); err == nil {
+			fileResult.InputSize = info.Size()
+		}
+		if result.Err != nil {
+			fileResult.Error = result.Err.Error()
+			fmt.Fprintf(os.Stderr, "Error processing %s: %s\n", result.SrcPath, result.Err.Error())
+		} else if info, err := os.Stat(result.DestPath); err == nil {
+			fileResult.OutputSize = info.Size()
+		}
+
+		report.Files = append(report.Files, fileResult)
+		if bar != nil {
+			bar.Add64(fileResult.InputSize)
+		}
+	}
+	if bar != nil {
+		bar.Finish()
+	}
+	return report
+}
+
+// parsePipeline traduit une spécification "stage1:args|stage2:args|..." (par
+// exemple "grayscale|blur:5.0|thumbnail:128") en une liste de pipeline.Stage
+// exécutables dans cet ordre.
+func parsePipeline(spec string) ([]pipeline.Stage, error) {
+	var stages []pipeline.Stage
+	for _, part := range strings.Split(spec, "|") {
+		name, args, _ := strings.Cut(part, ":")
+		switch name {
+		case "grayscale":
+			stages = append(stages, pipeline.Grayscale())
+		case "blur":
+			sigma, err := strconv.ParseFloat(args, 64)
+			if err != nil {
+				return nil, fmt.Errorf("blur: sigma invalide %q: %w", args, err)
+			}
+			stages = append(stages, pipeline.Blur(sigma))
+		case "resize":
+			width, height, err := parseDimensions(args)
+			if err != nil {
+				return nil, fmt.Errorf("resize: %w", err)
+			}
+			stages = append(stages, pipeline.Resize(width, height))
+		case "thumbnail":
+			size, err := strconv.Atoi(args)
+			if err != nil {
+				return nil, fmt.Errorf("thumbnail: taille invalide %q: %w", args, err)
+			}
+			stages = append(stages, pipeline.Thumbnail(size, size))
+		case "thumbnail-crop":
+			size, err := strconv.Atoi(args)
+			if err != nil {
+				return nil, fmt.Errorf("thumbnail-crop: taille invalide %q: %w", args, err)
+			}
+			stages = append(stages, pipeline.ThumbnailCrop(size))
+		case "rotate":
+			angle, err := strconv.ParseFloat(args, 64)
+			if err != nil {
+				return nil, fmt.Errorf("rotate: angle invalide %q: %w", args, err)
+			}
+			stages = append(stages, pipeline.Rotate(angle))
+		case "contrast":
+			percentage, err := strconv.ParseFloat(args, 64)
+			if err != nil {
+				return nil, fmt.Errorf("contrast: pourcentage invalide %q: %w", args, err)
+			}
+			stages = append(stages, pipeline.AdjustContrast(percentage))
+		default:
+			return nil, fmt.Errorf("étape de pipeline inconnue: %q", name)
+		}
+	}
+	return stages, nil
+}
+
+// parseDimensions découpe une spécification "LARGEURxHAUTEUR" en ses deux
+// composantes entières.
+func parseDimensions(spec string) (int, int, error) {
+	w, h, ok := strings.Cut(spec, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("format attendu LARGEURxHAUTEUR, reçu %q", spec)
 	}
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return 0, 0, fmt.Errorf("largeur invalide %q", w)
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, 0, fmt.Errorf("hauteur invalide %q", h)
+	}
+	return width, height, nil
 }
 
 func main() {
 	// Analyse des arguments de ligne de commande
 	srcPath := flag.String("src", "", "Source folder containing the images")
 	destPath := flag.String("dst", "", "Destination folder to save the filtered images")
-	filter := flag.String("filter", "", "Filter to apply (grayscale or blur)")
+	filter := flag.String("filter", "", "Filter to apply (grayscale, blur or thumbnail)")
 	task := flag.String("task", "", "Task method to use (waitgrp or channel)")
+	pipelineSpec := flag.String("pipeline", "", `Chain of filter stages to apply in one pass, e.g. "grayscale|blur:5.0|thumbnail:128"`)
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of worker goroutines per pipeline stage, or in the -task channel pool")
+	timeout := flag.Duration("timeout", 0, "Abort processing after this duration (e.g. 30s), 0 means no limit")
+	maxWidth := flag.Int("max-width", 0, "Maximum thumbnail width for -filter thumbnail")
+	maxHeight := flag.Int("max-height", 0, "Maximum thumbnail height for -filter thumbnail")
+	cropSquare := flag.Bool("crop-square", false, "With -filter thumbnail, center-crop to a square instead of preserving aspect ratio")
+	silent := flag.Bool("silent", false, "Suppress the progress bar")
+	noProgress := flag.Bool("no-progress", false, "Alias for -silent")
+	reportPath := flag.String("report", "", "Write the JSON processing report to this path instead of stdout")
+	cacheDir := flag.String("cache-dir", "", "Directory used to cache filter results, keyed by source content and filter params; disabled if empty")
+	cacheMaxBytes := flag.Int64("cache-max-bytes", 0, "With -cache-dir, evict the least recently used entries once the cache exceeds this size; 0 means unbounded")
 
 	flag.Parse()
 
+	thumbOpts := thumbnailOptions{maxWidth: *maxWidth, maxHeight: *maxHeight, cropSquare: *cropSquare}
+	progress := progressOptions{silent: *silent, noProgress: *noProgress, reportPath: *reportPath}
+
+	// Le cache est optionnel : -cache-dir vide désactive entièrement la
+	// fonctionnalité, ce qui laisse runFilter fonctionner comme avant son
+	// introduction.
+	var resultCache *cache.Cache
+	if *cacheDir != "" {
+		var err error
+		resultCache, err = cache.NewCache(*cacheDir)
+		if err != nil {
+			fmt.Printf("Error opening cache: %s\n", err.Error())
+			return
+		}
+		if *cacheMaxBytes > 0 {
+			defer resultCache.EvictLRU(*cacheMaxBytes)
+		}
+	}
+
+	// Le contexte racine est annulé sur SIGINT/SIGTERM ou, si -timeout est
+	// fourni, au bout de cette durée, ce qui interrompt les lectures/écritures
+	// en cours au lieu de laisser le dossier de destination dans un état
+	// incohérent.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	// Le mode pipeline compose librement plusieurs filtres en une seule
+	// lecture/écriture par fichier ; il coexiste avec le mode -filter/-task
+	// historique plutôt que de le remplacer.
+	if *pipelineSpec != "" {
+		if *srcPath == "" || *destPath == "" {
+			fmt.Println(`Usage: imggo -src <source_folder> -dst <destination_folder> -pipeline "grayscale|blur:5.0|thumbnail:128"`)
+			return
+		}
+
+		stages, err := parsePipeline(*pipelineSpec)
+		if err != nil {
+			fmt.Printf("Invalid pipeline: %s\n", err.Error())
+			return
+		}
+
+		// Note : pipeline.Source ne parcourt pas les sous-dossiers (contrairement
+		// à processImagesWithChannel), donc le décompte de la barre de
+		// progression se limite volontairement au dossier de plus haut niveau.
+		// Le cache de résultats n'est pas branché ici : des Stage comme
+		// Thumbnail/ThumbnailCrop renomment DestPath via un suffixe interne au
+		// package pipeline, ce qui empêche main de prédire la clé de cache
+		// correspondant au fichier réellement écrit.
+		fileList, err := ioutil.ReadDir(*srcPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading directory: %s\n", err.Error())
+			return
+		}
+
+		bar := newProgressBar(progress, len(fileList), totalSize(fileList))
+
+		results, err := pipeline.Run(ctx, *srcPath, *destPath, stages, *workers)
+		if err != nil {
+			fmt.Printf("Error running pipeline: %s\n", err.Error())
+			return
+		}
+
+		writeReport(pipelineReport(results, *pipelineSpec, bar), progress)
+		return
+	}
+
 	// Vérification des arguments requis
 	if *srcPath == "" || *destPath == "" || *filter == "" || *task == "" {
 		fmt.Println("Usage: imggo -src <source_folder> -dst <destination_folder> -filter <filter_type> -task <task_method>")
@@ -194,9 +620,9 @@ func main() {
 	// Exécution de la méthode de traitement appropriée en fonction du paramètre de tâche
 	switch *task {
 	case "waitgrp":
-		processImagesWithWaitGroup(*srcPath, *destPath, *filter)
+		processImagesWithWaitGroup(ctx, *srcPath, *destPath, *filter, thumbOpts, progress, resultCache)
 	case "channel":
-		processImagesWithChannel(*srcPath, *destPath, *filter)
+		processImagesWithChannel(ctx, *srcPath, *destPath, *filter, thumbOpts, progress, resultCache, *workers)
 	default:
 		fmt.Printf("Invalid task method: %s\n", *task)
 	}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// generateBenchImages crée n images PNG factices de 64x64 pixels dans dir,
+// pour donner aux benchmarks un jeu de fichiers réaliste sans dépendre
+// d'images réelles présentes sur le disque.
+func generateBenchImages(b *testing.B, dir string, n int) {
+	b.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("img-%d.png", i)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		err = png.Encode(f, img)
+		f.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkProcessImagesWithChannel mesure le débit et les allocations du
+// pool de workers borné sur un dossier source de 10 000 images, avec
+// -workers réglé au nombre de CPU. À comparer, via `go test -bench -benchmem`,
+// à une version antérieure lançant un goroutine par fichier : le pool borné
+// doit maintenir une mémoire allouée stable à mesure que le nombre de
+// fichiers augmente, là où l'ancienne implémentation croît linéairement.
+func BenchmarkProcessImagesWithChannel(b *testing.B) {
+	src := b.TempDir()
+	generateBenchImages(b, src, 10000)
+
+	progress := progressOptions{silent: true, reportPath: filepath.Join(b.TempDir(), "report.json")}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst := b.TempDir()
+		processImagesWithChannel(context.Background(), src, dst, "grayscale", thumbnailOptions{}, progress, nil, runtime.NumCPU())
+	}
+}
+
+// BenchmarkProcessImagesWithChannelWorkerCounts compare le débit du pool pour
+// différentes tailles de pool sur le même jeu de 10 000 images.
+func BenchmarkProcessImagesWithChannelWorkerCounts(b *testing.B) {
+	src := b.TempDir()
+	generateBenchImages(b, src, 10000)
+
+	for _, workers := range []int{1, 2, 4, runtime.NumCPU()} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			progress := progressOptions{silent: true, reportPath: filepath.Join(b.TempDir(), "report.json")}
+			for i := 0; i < b.N; i++ {
+				dst := b.TempDir()
+				processImagesWithChannel(context.Background(), src, dst, "grayscale", thumbnailOptions{}, progress, nil, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cancelAfterFirstCheck se comporte comme un contexte non annulé la première
+// fois que Err() est appelé, puis comme un contexte annulé ensuite. Cela
+// permet de simuler une annulation survenant pendant le traitement d'un
+// filtre (entre l'ouverture de l'image source et son enregistrement), sans
+// dépendre du timing réel d'une goroutine concurrente.
+type cancelAfterFirstCheck struct {
+	context.Context
+	calls int
+}
+
+func (c *cancelAfterFirstCheck) Err() error {
+	c.calls++
+	if c.calls < 2 {
+		return nil
+	}
+	return context.Canceled
+}
+
+// writeTestPNG écrit une image PNG valide de 8x8 pixels à path, pour donner
+// aux filtres une source réelle plutôt que des octets arbitraires.
+func writeTestPNG(t *testing.T, path string) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 16), uint8(y * 16), 0, 255})
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("os.Create: %v", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+}
+
+func TestApplyGrayscaleFilterCleansUpOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.png")
+	dest := filepath.Join(dir, "dest.png")
+	writeTestPNG(t, src)
+
+	ctx := &cancelAfterFirstCheck{Context: context.Background()}
+	err := applyGrayscaleFilter(ctx, src, dest)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("applyGrayscaleFilter: got err %v, want context.Canceled", err)
+	}
+	if _, statErr := os.Stat(dest); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("applyGrayscaleFilter: expected %s to be cleaned up, stat err = %v", dest, statErr)
+	}
+}
+
+func TestApplyBlurFilterCleansUpOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.png")
+	dest := filepath.Join(dir, "dest.png")
+	writeTestPNG(t, src)
+
+	ctx := &cancelAfterFirstCheck{Context: context.Background()}
+	err := applyBlurFilter(ctx, src, dest)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("applyBlurFilter: got err %v, want context.Canceled", err)
+	}
+	if _, statErr := os.Stat(dest); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("applyBlurFilter: expected %s to be cleaned up, stat err = %v", dest, statErr)
+	}
+}
+
+func TestApplyThumbnailFilterCleansUpOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.png")
+	dest := filepath.Join(dir, "dest.png")
+	writeTestPNG(t, src)
+
+	ctx := &cancelAfterFirstCheck{Context: context.Background()}
+	opts := thumbnailOptions{maxWidth: 4, maxHeight: 4}
+	err := applyThumbnailFilter(ctx, src, dest, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("applyThumbnailFilter: got err %v, want context.Canceled", err)
+	}
+	destThumb := thumbSuffix(dest)
+	if _, statErr := os.Stat(destThumb); !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("applyThumbnailFilter: expected %s to be cleaned up, stat err = %v", destThumb, statErr)
+	}
+}
@@ -0,0 +1,159 @@
+// Package pipeline fournit une chaîne de traitement d'images composée de
+// Stage réutilisables (niveaux de gris, flou, redimensionnement, ...) reliés
+// par des canaux, à la manière du pattern pipeline de Go : un Source décode
+// les fichiers d'un dossier, chaque Stage transforme les images qui y
+// transitent, et un Sink les encode vers le dossier de destination.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+// Image transporte une image décodée ainsi que les chemins source et
+// destination nécessaires pour la réécrire une fois tous les Stage appliqués.
+// Err est propagé d'étape en étape : un Stage ne doit pas tenter de
+// transformer une Image dont Err est déjà renseigné.
+type Image struct {
+	SrcPath  string
+	DestPath string
+	Img      image.Image
+	Err      error
+}
+
+// Stage consomme les images reçues sur in et publie le résultat sur out.
+// Un Stage ne doit pas fermer out : c'est à l'appelant (Pool, Run) de le
+// faire une fois tous les goroutines du Stage terminés.
+type Stage func(in <-chan Image, out chan<- Image)
+
+// Source parcourt srcDir et émet une Image décodée pour chaque fichier
+// rencontré, en faisant correspondre son chemin de destination sous destDir.
+// Elle s'arrête dès que ctx est annulé, sans décoder les fichiers restants.
+func Source(ctx context.Context, srcDir, destDir string) (<-chan Image, error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline: lecture de %s: %w", srcDir, err)
+	}
+
+	out := make(chan Image)
+	go func() {
+		defer close(out)
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if entry.IsDir() {
+				continue
+			}
+
+			srcFilePath := filepath.Join(srcDir, entry.Name())
+			destFilePath := filepath.Join(destDir, entry.Name())
+
+			img, err := imaging.Open(srcFilePath)
+			select {
+			case out <- Image{SrcPath: srcFilePath, DestPath: destFilePath, Img: img, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Result décrit le sort d'une Image une fois passée par Sink : ses chemins
+// source et destination (ce dernier éventuellement modifié par un Stage,
+// par exemple suffixé par Thumbnail), et une éventuelle erreur.
+type Result struct {
+	SrcPath  string
+	DestPath string
+	Err      error
+}
+
+// Sink encode chaque Image reçue et l'écrit vers DestPath, en renvoyant un
+// Result par image sur le canal retourné. Si ctx est annulé, le fichier de
+// destination en cours d'écriture est supprimé plutôt que d'être laissé
+// dans un état partiel.
+func Sink(ctx context.Context, in <-chan Image) <-chan Result {
+	results := make(chan Result)
+	go func() {
+		defer close(results)
+		for img := range in {
+			result := Result{SrcPath: img.SrcPath, DestPath: img.DestPath}
+
+			switch {
+			case img.Err != nil:
+				result.Err = fmt.Errorf("%s: %w", img.SrcPath, img.Err)
+			default:
+				if err := imaging.Save(img.Img, img.DestPath); err != nil {
+					result.Err = fmt.Errorf("%s: %w", img.DestPath, err)
+				} else if ctx.Err() != nil {
+					os.Remove(img.DestPath)
+					result.Err = fmt.Errorf("%s: %w", img.DestPath, ctx.Err())
+				}
+			}
+
+			results <- result
+		}
+	}()
+	return results
+}
+
+// Pool fait tourner stage sur n goroutines partageant les mêmes canaux in et
+// out, ce qui donne à l'étape une forme de backpressure grâce au buffer de
+// out plutôt que de traiter les images une par une.
+func Pool(n int, stage Stage) Stage {
+	if n < 1 {
+		n = 1
+	}
+	return func(in <-chan Image, out chan<- Image) {
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func() {
+				defer wg.Done()
+				stage(in, out)
+			}()
+		}
+		wg.Wait()
+		close(out)
+	}
+}
+
+// Run relie srcDir à destDir en faisant passer chaque image par Source, puis
+// par chaque Stage de stages dans l'ordre (chacun exécuté sur workers
+// goroutines via Pool), puis par Sink. Elle bloque jusqu'à ce que toutes les
+// images aient été traitées, ou que ctx soit annulé, et renvoie un Result par
+// fichier traité (son DestPath final et une éventuelle erreur).
+func Run(ctx context.Context, srcDir, destDir string, stages []Stage, workers int) ([]Result, error) {
+	cur, err := Source(ctx, srcDir, destDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stage := range stages {
+		out := make(chan Image, workers)
+		go Pool(workers, stage)(cur, out)
+		cur = out
+	}
+
+	var results []Result
+	for result := range Sink(ctx, cur) {
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// withSuffix insère suffix juste avant l'extension de path, par exemple
+// withSuffix("photo.jpg", "_thumb") renvoie "photo_thumb.jpg".
+func withSuffix(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + suffix + ext
+}
@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/disintegration/imaging"
+)
+
+// thumbnailSize calcule les dimensions d'une vignette tenant dans
+// maxWidth x maxHeight en conservant le ratio d'aspect de srcW x srcH, sans
+// jamais agrandir l'image source.
+func thumbnailSize(srcW, srcH, maxWidth, maxHeight int) (width, height int) {
+	scale := minFloat(float64(maxWidth)/float64(srcW), float64(maxHeight)/float64(srcH))
+	if scale > 1 {
+		scale = 1
+	}
+
+	width = int(float64(srcW) * scale)
+	height = int(float64(srcH) * scale)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Grayscale convertit chaque image reçue en niveaux de gris.
+func Grayscale() Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				img.Img = imaging.Grayscale(img.Img)
+			}
+			out <- img
+		}
+	}
+}
+
+// Blur applique un flou gaussien d'écart-type sigma à chaque image reçue.
+func Blur(sigma float64) Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				img.Img = imaging.Blur(img.Img, sigma)
+			}
+			out <- img
+		}
+	}
+}
+
+// Resize redimensionne chaque image à width x height. Une dimension à 0 est
+// calculée pour conserver le ratio d'aspect de l'image source.
+func Resize(width, height int) Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				img.Img = imaging.Resize(img.Img, width, height, imaging.Lanczos)
+			}
+			out <- img
+		}
+	}
+}
+
+// Thumbnail réduit chaque image pour qu'elle tienne dans maxWidth x
+// maxHeight, sans jamais l'agrandir ni déformer son ratio d'aspect, et
+// suffixe le chemin de destination avec "_thumb".
+func Thumbnail(maxWidth, maxHeight int) Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				if maxWidth <= 0 || maxHeight <= 0 {
+					img.Err = fmt.Errorf("pipeline: Thumbnail: maxWidth et maxHeight doivent être positifs (reçu %dx%d)", maxWidth, maxHeight)
+					out <- img
+					continue
+				}
+				bounds := img.Img.Bounds()
+				width, height := thumbnailSize(bounds.Dx(), bounds.Dy(), maxWidth, maxHeight)
+				img.Img = imaging.Resize(img.Img, width, height, imaging.Lanczos)
+				img.DestPath = withSuffix(img.DestPath, "_thumb")
+			}
+			out <- img
+		}
+	}
+}
+
+// ThumbnailCrop centre-rogne chaque image en carré puis la redimensionne à
+// size x size, et suffixe le chemin de destination avec "_thumb".
+func ThumbnailCrop(size int) Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				if size <= 0 {
+					img.Err = fmt.Errorf("pipeline: ThumbnailCrop: size doit être positif (reçu %d)", size)
+					out <- img
+					continue
+				}
+				img.Img = imaging.Fill(img.Img, size, size, imaging.Center, imaging.Lanczos)
+				img.DestPath = withSuffix(img.DestPath, "_thumb")
+			}
+			out <- img
+		}
+	}
+}
+
+// Rotate fait pivoter chaque image de angle degrés dans le sens
+// anti-horaire.
+func Rotate(angle float64) Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				img.Img = imaging.Rotate(img.Img, angle, color.Transparent)
+			}
+			out <- img
+		}
+	}
+}
+
+// AdjustContrast modifie le contraste de chaque image de percentage points,
+// où percentage est compris entre -100 (contraste minimal) et 100
+// (contraste maximal).
+func AdjustContrast(percentage float64) Stage {
+	return func(in <-chan Image, out chan<- Image) {
+		for img := range in {
+			if img.Err == nil {
+				img.Img = imaging.AdjustContrast(img.Img, percentage)
+			}
+			out <- img
+		}
+	}
+}
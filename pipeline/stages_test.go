@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"image"
+	"testing"
+)
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		name                  string
+		srcW, srcH            int
+		maxWidth, maxHeight   int
+		wantWidth, wantHeight int
+	}{
+		{
+			name: "reduit en conservant le ratio d'aspect",
+			srcW: 200, srcH: 100, maxWidth: 100, maxHeight: 100,
+			wantWidth: 100, wantHeight: 50,
+		},
+		{
+			name: "contrainte par la hauteur plutot que la largeur",
+			srcW: 100, srcH: 200, maxWidth: 100, maxHeight: 50,
+			wantWidth: 25, wantHeight: 50,
+		},
+		{
+			name: "n'agrandit jamais l'image source",
+			srcW: 50, srcH: 50, maxWidth: 200, maxHeight: 200,
+			wantWidth: 50, wantHeight: 50,
+		},
+		{
+			name: "image carree dans un cadre carre",
+			srcW: 64, srcH: 64, maxWidth: 32, maxHeight: 32,
+			wantWidth: 32, wantHeight: 32,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotWidth, gotHeight := thumbnailSize(tt.srcW, tt.srcH, tt.maxWidth, tt.maxHeight)
+			if gotWidth != tt.wantWidth || gotHeight != tt.wantHeight {
+				t.Fatalf("thumbnailSize(%d, %d, %d, %d) = (%d, %d), want (%d, %d)",
+					tt.srcW, tt.srcH, tt.maxWidth, tt.maxHeight, gotWidth, gotHeight, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestThumbnailRejectsNonPositiveDimensions(t *testing.T) {
+	img := Image{Img: image.NewRGBA(image.Rect(0, 0, 10, 10))}
+	in := make(chan Image, 1)
+	out := make(chan Image, 1)
+	in <- img
+	close(in)
+
+	Thumbnail(0, 10)(in, out)
+	close(out)
+
+	result := <-out
+	if result.Err == nil {
+		t.Fatal("Thumbnail(0, 10): expected an error, got nil")
+	}
+}
+
+func TestThumbnailCropRejectsNonPositiveSize(t *testing.T) {
+	img := Image{Img: image.NewRGBA(image.Rect(0, 0, 10, 10))}
+	in := make(chan Image, 1)
+	out := make(chan Image, 1)
+	in <- img
+	close(in)
+
+	ThumbnailCrop(0)(in, out)
+	close(out)
+
+	result := <-out
+	if result.Err == nil {
+		t.Fatal("ThumbnailCrop(0): expected an error, got nil")
+	}
+}
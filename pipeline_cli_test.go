@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{name: "valide", spec: "800x600", wantWidth: 800, wantHeight: 600},
+		{name: "sans separateur", spec: "800", wantErr: true},
+		{name: "largeur invalide", spec: "abcx600", wantErr: true},
+		{name: "hauteur invalide", spec: "800xabc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := parseDimensions(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDimensions(%q): expected an error, got nil", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDimensions(%q): unexpected error: %v", tt.spec, err)
+			}
+			if width != tt.wantWidth || height != tt.wantHeight {
+				t.Fatalf("parseDimensions(%q) = (%d, %d), want (%d, %d)", tt.spec, width, height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "une seule etape", spec: "grayscale", wantCount: 1},
+		{name: "plusieurs etapes", spec: "grayscale|blur:5.0|thumbnail:128", wantCount: 3},
+		{name: "thumbnail-crop et transformations", spec: "thumbnail-crop:64|rotate:90|contrast:10", wantCount: 3},
+		{name: "resize avec dimensions", spec: "resize:100x200", wantCount: 1},
+		{name: "etape inconnue", spec: "sepia", wantErr: true},
+		{name: "argument blur invalide", spec: "blur:abc", wantErr: true},
+		{name: "argument resize invalide", spec: "resize:abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := parsePipeline(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePipeline(%q): expected an error, got nil", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePipeline(%q): unexpected error: %v", tt.spec, err)
+			}
+			if len(stages) != tt.wantCount {
+				t.Fatalf("parsePipeline(%q): got %d stages, want %d", tt.spec, len(stages), tt.wantCount)
+			}
+		})
+	}
+}
+
+// writeNestedTestImages crée sous srcDir l'arborescence suivante, chacune
+// contenant une image PNG valide :
+//
+//	a.png
+//	sub/b.png
+//	sub/nested/c.png
+func writeNestedTestImages(t *testing.T, srcDir string) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 16), uint8(y * 16), 0, 255})
+		}
+	}
+
+	paths := []string{
+		filepath.Join(srcDir, "a.png"),
+		filepath.Join(srcDir, "sub", "b.png"),
+		filepath.Join(srcDir, "sub", "nested", "c.png"),
+	}
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("os.MkdirAll: %v", err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatalf("os.Create: %v", err)
+		}
+		err = png.Encode(f, img)
+		f.Close()
+		if err != nil {
+			t.Fatalf("png.Encode: %v", err)
+		}
+	}
+}
+
+// TestProcessImagesWithChannelMirrorsDirectories vérifie que
+// processImagesWithChannel (via walkJobs) reproduit sous destDir
+// l'arborescence de sous-dossiers de srcDir, plutôt que d'aplatir tous les
+// fichiers dans un seul dossier.
+func TestProcessImagesWithChannelMirrorsDirectories(t *testing.T) {
+	srcDir := t.TempDir()
+	destDir := t.TempDir()
+	writeNestedTestImages(t, srcDir)
+
+	progress := progressOptions{silent: true, reportPath: filepath.Join(t.TempDir(), "report.json")}
+	processImagesWithChannel(context.Background(), srcDir, destDir, "grayscale", thumbnailOptions{}, progress, nil, 2)
+
+	wantPaths := []string{
+		filepath.Join(destDir, "a.png"),
+		filepath.Join(destDir, "sub", "b.png"),
+		filepath.Join(destDir, "sub", "nested", "c.png"),
+	}
+	for _, path := range wantPaths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected mirrored output at %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	pb "github.com/cheggaaa/pb/v3"
+)
+
+// FileResult décrit le résultat du traitement d'un fichier : ses chemins
+// d'entrée et de sortie, la chaîne de filtres appliquée, les tailles avant et
+// après traitement, le temps écoulé, et une éventuelle erreur.
+type FileResult struct {
+	InputPath   string  `json:"input_path"`
+	OutputPath  string  `json:"output_path"`
+	FilterChain string  `json:"filter_chain"`
+	InputSize   int64   `json:"input_size"`
+	OutputSize  int64   `json:"output_size"`
+	ElapsedMS   float64 `json:"elapsed_ms"`
+	CacheHit    bool    `json:"cache_hit,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Report est le résumé JSON émis en fin de traitement, un FileResult par
+// fichier source rencontré.
+type Report struct {
+	Files []FileResult `json:"files"`
+}
+
+// progressOptions contrôle l'affichage de la barre de progression et
+// l'emplacement du rapport JSON produit en fin de traitement.
+type progressOptions struct {
+	silent     bool
+	noProgress bool
+	reportPath string
+}
+
+// newProgressBar construit une barre de progression qui avance en octets :
+// elle montre les octets traités sur le total, le nombre total de fichiers,
+// l'ETA et le débit, ou renvoie nil si opts désactive l'affichage. Le nombre
+// de fichiers déjà traités n'y est pas affiché.
+func newProgressBar(opts progressOptions, totalFiles int, totalBytes int64) *pb.ProgressBar {
+	if opts.silent || opts.noProgress {
+		return nil
+	}
+
+	bar := pb.New64(totalBytes)
+	bar.Set(pb.Bytes, true)
+	bar.SetTemplateString(fmt.Sprintf(`{{counters . }} (%d files) {{bar . }} {{percent . }} {{speed . }} ETA {{rtime . }}`, totalFiles))
+	bar.SetWriter(os.Stderr)
+	bar.Start()
+	return bar
+}
+
+// collectResults vide ch, fait avancer bar (si non nil) et journalise les
+// erreurs par fichier sur stderr, puis renvoie le Report agrégé une fois ch
+// fermé.
+func collectResults(ch <-chan FileResult, bar *pb.ProgressBar) Report {
+	var report Report
+	for result := range ch {
+		report.Files = append(report.Files, result)
+		if result.Error != "" {
+			fmt.Fprintf(os.Stderr, "Error processing %s: %s\n", result.InputPath, result.Error)
+		}
+		if bar != nil {
+			bar.Add64(result.InputSize)
+		}
+	}
+	if bar != nil {
+		bar.Finish()
+	}
+	return report
+}
+
+// writeReport sérialise report en JSON et l'écrit vers opts.reportPath, ou
+// sur stdout si aucun chemin n'a été fourni.
+func writeReport(report Report, opts progressOptions) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling report: %s\n", err.Error())
+		return
+	}
+
+	if opts.reportPath == "" {
+		fmt.Println(string(data))
+		return
+	}
+
+	if err := os.WriteFile(opts.reportPath, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report to %s: %s\n", opts.reportPath, err.Error())
+	}
+}